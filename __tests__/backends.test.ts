@@ -0,0 +1,581 @@
+/**
+ * Tests for the remote notification backends
+ *
+ * Each scenario writes opencode-hyprland.json into a temporary HOME and
+ * imports a fresh copy of the plugin, since the config is cached per module
+ */
+
+import { describe, it, expect, beforeAll, afterAll, afterEach } from "bun:test"
+import { mkdtempSync, mkdirSync, writeFileSync, readFileSync, existsSync, rmSync } from "fs"
+import { join } from "path"
+import { tmpdir } from "os"
+import { createHmac } from "crypto"
+import { createMockContext, eventFixtures } from "./fixtures"
+
+// ============================================================================
+// TEST HARNESS
+// ============================================================================
+
+interface PluginHooks {
+  event: (input: { event: any }) => Promise<void>
+}
+
+interface CapturedRequest {
+  readonly method: string
+  readonly path: string
+  readonly headers: Headers
+  readonly body: string
+}
+
+interface TestServer {
+  readonly url: string
+  readonly requests: CapturedRequest[]
+}
+
+const originalHome = process.env.HOME
+let home = ""
+let moduleInstance = 0
+const servers: { stop: (closeActiveConnections?: boolean) => void }[] = []
+
+beforeAll(() => {
+  home = mkdtempSync(join(tmpdir(), "hypr-notifier-test-"))
+  mkdirSync(join(home, ".config", "opencode"), { recursive: true })
+  process.env.HOME = home
+})
+
+afterAll(() => {
+  if (originalHome === undefined) delete process.env.HOME
+  else process.env.HOME = originalHome
+  rmSync(home, { recursive: true, force: true })
+})
+
+afterEach(() => {
+  for (const server of servers.splice(0)) server.stop(true)
+})
+
+// Desktop popups are disabled so only the configured backend is exercised
+async function loadModule(config: Record<string, unknown>): Promise<any> {
+  writeFileSync(
+    join(home, ".config", "opencode", "opencode-hyprland.json"),
+    JSON.stringify({ notification: false, ...config })
+  )
+  return import(`../src/hypr-notifier.ts?instance=${moduleInstance++}`)
+}
+
+async function loadPlugin(config: Record<string, unknown>): Promise<PluginHooks> {
+  const module = await loadModule(config)
+  return module.HyprNotifierPlugin(createMockContext() as any)
+}
+
+function createProject(config: Record<string, unknown>): string {
+  const worktree = mkdtempSync(join(home, "project-"))
+  mkdirSync(join(worktree, ".opencode"))
+  writeFileSync(join(worktree, ".opencode", "opencode-hyprland.json"), JSON.stringify(config))
+  return worktree
+}
+
+// Responds with the given statuses in order, then 200
+function startServer(statuses: number[] = []): TestServer {
+  const requests: CapturedRequest[] = []
+  const server = Bun.serve({
+    port: 0,
+    async fetch(req) {
+      requests.push({
+        method: req.method,
+        path: new URL(req.url).pathname,
+        headers: req.headers,
+        body: await req.text(),
+      })
+      return new Response(null, { status: statuses[requests.length - 1] ?? 200 })
+    },
+  })
+  servers.push(server)
+  return { url: `http://127.0.0.1:${server.port}`, requests }
+}
+
+// ============================================================================
+// WEBHOOK
+// ============================================================================
+
+describe("Webhook Backend", () => {
+  it("should sign the raw body with HMAC-SHA256", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, secret: "s3cret", events: ["permission"] } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    expect(server.requests).toHaveLength(1)
+    const request = server.requests[0]!
+    const expected = `sha256=${createHmac("sha256", "s3cret").update(request.body).digest("hex")}`
+    expect(request.headers.get("X-Hypr-Notifier-Signature")).toBe(expected)
+  })
+
+  it("should post the documented payload", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["permission"] } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    const request = server.requests[0]!
+    expect(request.headers.get("X-Hypr-Notifier-Signature")).toBeNull()
+    const payload = JSON.parse(request.body)
+    expect(payload.event).toBe("permission")
+    expect(payload.type).toBe("permission.updated")
+    expect(payload.title).toBe("OpenCode Needs Your Attention")
+    expect(payload.urgency).toBe("critical")
+    expect(payload.body).toContain("Action: Make HTTP Request")
+    expect(payload.properties).toEqual(eventFixtures.permissionEvent.properties)
+    expect(typeof payload.timestamp).toBe("string")
+  })
+
+  it("should retry a 503 until it succeeds", async () => {
+    const server = startServer([503, 200])
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["permission"] } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    expect(server.requests).toHaveLength(2)
+    expect(server.requests[1]!.body).toBe(server.requests[0]!.body)
+  })
+
+  it("should keep one delivery ID per event across retries", async () => {
+    const server = startServer([503, 200])
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["permission", "session"] } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+    await hooks.event({ event: eventFixtures.sessionCompleteEvent })
+
+    expect(server.requests).toHaveLength(3)
+    const ids = server.requests.map((request) => request.headers.get("X-Hypr-Notifier-Delivery"))
+    expect(ids[0]).toBeTruthy()
+    expect(ids[1]).toBe(ids[0])
+    expect(ids[2]).not.toBe(ids[0])
+    expect(server.requests.map((request) => JSON.parse(request.body).id)).toEqual(ids)
+  })
+
+  it("should not retry a 400", async () => {
+    const server = startServer([400])
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["permission"] } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    expect(server.requests).toHaveLength(1)
+  })
+
+  it("should skip events that are not selected", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["error"] } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    expect(server.requests).toHaveLength(0)
+  })
+})
+
+// ============================================================================
+// SESSION ERROR BODY
+// ============================================================================
+
+describe("Session Error Notifications", () => {
+  it("should render message, error name and session", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["error"] } })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    const payload = JSON.parse(server.requests[0]!.body)
+    expect(payload.title).toBe("OpenCode Session Error")
+    expect(payload.urgency).toBe("critical")
+    expect(payload.body).toBe("Rate limit exceeded\nError: APIError\nSession: sess_error_123")
+  })
+
+  it("should fall back to a generic message", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["error"] } })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEventMinimal })
+
+    expect(JSON.parse(server.requests[0]!.body).body).toBe("OpenCode encountered an error")
+  })
+
+  it("should not notify when the user aborts a message", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["error", "idle"] } })
+
+    await hooks.event({ event: eventFixtures.sessionAbortedEvent })
+    await hooks.event({ event: eventFixtures.idleEvent })
+
+    expect(server.requests).toHaveLength(1)
+    expect(JSON.parse(server.requests[0]!.body).event).toBe("idle")
+  })
+
+  it("should suppress the idle notification that follows an error", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ webhook: { url: server.url, events: ["error", "idle"] } })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+    await hooks.event({ event: eventFixtures.idleEvent })
+
+    expect(server.requests).toHaveLength(1)
+    expect(JSON.parse(server.requests[0]!.body).event).toBe("error")
+  })
+})
+
+// ============================================================================
+// SLACK
+// ============================================================================
+
+describe("Slack Backend", () => {
+  it("should post an escaped message with a bold title", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({
+      slack: { webhookUrl: server.url },
+      messages: { permission: "A & B <needs> you" },
+    })
+
+    await hooks.event({
+      event: { type: "permission.updated", properties: { type: "file", pattern: "<src> & <dist>" } },
+    })
+
+    expect(server.requests).toHaveLength(1)
+    const payload = JSON.parse(server.requests[0]!.body)
+    expect(payload.text).toBe("*A &amp; B &lt;needs&gt; you*\nType: file\nResource: &lt;src&gt; &amp; &lt;dist&gt;")
+  })
+
+  it("should not post idle events by default", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ slack: { webhookUrl: server.url } })
+
+    await hooks.event({ event: eventFixtures.idleEvent })
+
+    expect(server.requests).toHaveLength(0)
+  })
+
+  it("should post idle events when listed", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ slack: { webhookUrl: server.url, events: ["idle"] } })
+
+    await hooks.event({ event: eventFixtures.idleEvent })
+
+    expect(server.requests).toHaveLength(1)
+    expect(JSON.parse(server.requests[0]!.body).text).toStartWith("*OpenCode Waiting for Input*\n")
+  })
+
+  it("should deliver while desktop notifications are disabled", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ notification: false, slack: { webhookUrl: server.url } })
+
+    await hooks.event({ event: eventFixtures.sessionCompleteEvent })
+
+    expect(server.requests).toHaveLength(1)
+  })
+})
+
+// ============================================================================
+// DISCORD
+// ============================================================================
+
+describe("Discord Backend", () => {
+  it("should post an embed colored by urgency with mentions disabled", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ discord: { webhookUrl: server.url } })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    expect(server.requests).toHaveLength(1)
+    const payload = JSON.parse(server.requests[0]!.body)
+    expect(payload.username).toBe("OpenCode")
+    expect(payload.allowed_mentions).toEqual({ parse: [] })
+    expect(payload.embeds).toEqual([
+      {
+        title: "OpenCode Session Error",
+        description: "Rate limit exceeded\nError: APIError\nSession: sess_error_123",
+        color: 0xe74c3c,
+      },
+    ])
+  })
+
+  it("should truncate oversized titles and descriptions", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({
+      discord: { webhookUrl: server.url },
+      messages: { permission: "t".repeat(300) },
+    })
+
+    await hooks.event({ event: { type: "permission.updated", properties: { pattern: "p".repeat(5000) } } })
+
+    const [embed] = JSON.parse(server.requests[0]!.body).embeds
+    expect(embed.title).toHaveLength(256)
+    expect(embed.title).toEndWith("…")
+    expect(embed.description).toHaveLength(4096)
+    expect(embed.description).toEndWith("…")
+    expect(embed.color).toBe(0xe74c3c)
+  })
+
+  it("should not split an emoji at the title boundary", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({
+      discord: { webhookUrl: server.url },
+      messages: { permission: `${"t".repeat(254)}🔒🔒🔒` },
+    })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    const [embed] = JSON.parse(server.requests[0]!.body).embeds
+    expect(embed.title).toBe(`${"t".repeat(254)}🔒…`)
+    expect(Array.from(embed.title as string)).toHaveLength(256)
+  })
+})
+
+// ============================================================================
+// EMAIL
+// ============================================================================
+
+describe("Email Backend", () => {
+  const originalPath = process.env.PATH
+  let captureDir = ""
+
+  // Stand-in for curl that records argv, stdin and the uploaded message
+  beforeAll(() => {
+    const binDir = mkdtempSync(join(home, "bin-"))
+    captureDir = mkdtempSync(join(home, "capture-"))
+    writeFileSync(
+      join(binDir, "curl"),
+      [
+        "#!/bin/sh",
+        `out="${captureDir}"`,
+        `printf '%s\\n' "$@" > "$out/argv"`,
+        `cat > "$out/stdin"`,
+        "while [ $# -gt 0 ]; do",
+        `  if [ "$1" = "--upload-file" ]; then cp "$2" "$out/message"; fi`,
+        "  shift",
+        "done",
+        "",
+      ].join("\n"),
+      { mode: 0o755 }
+    )
+    process.env.PATH = `${binDir}:${originalPath ?? ""}`
+  })
+
+  afterAll(() => {
+    process.env.PATH = originalPath
+  })
+
+  function captured(name: string): string {
+    return readFileSync(join(captureDir, name), "utf-8")
+  }
+
+  it("should build a MIME message with encoded subject and body", async () => {
+    const hooks = await loadPlugin({
+      email: { url: "smtp://127.0.0.1:2525", from: "opencode@example.com", to: ["me@example.com", "you@example.com"], events: ["error"] },
+      messages: { error: "Fehler ü\r\nBcc: evil@example.com" },
+    })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    const [head = "", encodedBody = ""] = captured("message").split("\r\n\r\n")
+    const headers = head.split("\r\n")
+    expect(headers).toContain("From: opencode@example.com")
+    expect(headers).toContain("To: me@example.com, you@example.com")
+    expect(headers).toContain(`Subject: =?UTF-8?B?${Buffer.from("Fehler ü Bcc: evil@example.com").toString("base64")}?=`)
+    expect(headers.filter((header) => /^Message-ID: <[0-9a-f-]{36}@example\.com>$/.test(header))).toHaveLength(1)
+    expect(headers).toContain("Content-Type: text/plain; charset=utf-8")
+    expect(headers).toContain("Content-Transfer-Encoding: base64")
+    expect(headers.some((header) => header.startsWith("Bcc:"))).toBe(false)
+    expect(Buffer.from(encodedBody.replace(/\r\n/g, ""), "base64").toString("utf-8")).toBe(
+      "Rate limit exceeded\nError: APIError\nSession: sess_error_123"
+    )
+  })
+
+  it("should disable email for addresses that are not bare ASCII", async () => {
+    rmSync(join(captureDir, "argv"), { force: true })
+    const invalid = [
+      { from: "opencode@example.com\r\nBcc: evil@example.com", to: "me@example.com" },
+      { from: "Jürgen <opencode@example.com>", to: "me@example.com" },
+      { from: "opencode@example.com", to: ["me@example.com", "björn@exämple.com"] },
+    ]
+
+    for (const addresses of invalid) {
+      const hooks = await loadPlugin({ email: { url: "smtp://127.0.0.1:2525", events: ["error"], ...addresses } })
+      await hooks.event({ event: eventFixtures.sessionErrorEvent })
+    }
+
+    expect(existsSync(join(captureDir, "argv"))).toBe(false)
+  })
+
+  it("should pass credentials on stdin and require TLS", async () => {
+    const hooks = await loadPlugin({
+      email: {
+        url: "smtp://127.0.0.1:2525",
+        from: "opencode@example.com",
+        to: "me@example.com",
+        username: "opencode@example.com",
+        password: 'pa"ss\\word',
+        events: ["error"],
+      },
+    })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    const argv = captured("argv").trimEnd().split("\n")
+    expect(argv.join(" ")).not.toContain("pa\"ss")
+    expect(argv).toContain("--ssl-reqd")
+    expect(argv.slice(argv.indexOf("--config"), argv.indexOf("--config") + 2)).toEqual(["--config", "-"])
+    expect(argv.slice(argv.indexOf("--mail-rcpt"), argv.indexOf("--mail-rcpt") + 2)).toEqual(["--mail-rcpt", "me@example.com"])
+    expect(captured("stdin")).toBe('user = "opencode@example.com:pa\\"ss\\\\word"\n')
+
+    // The message directory is removed once curl exits
+    const uploadPath = argv[argv.indexOf("--upload-file") + 1]!
+    expect(existsSync(uploadPath)).toBe(false)
+  })
+
+  it("should not require TLS or send a config without credentials", async () => {
+    const hooks = await loadPlugin({
+      email: { url: "smtp://127.0.0.1:2525", from: "opencode@example.com", to: "me@example.com", events: ["error"] },
+    })
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    const argv = captured("argv").trimEnd().split("\n")
+    expect(argv).not.toContain("--ssl-reqd")
+    expect(argv).not.toContain("--config")
+    expect(captured("stdin")).toBe("")
+  })
+})
+
+// ============================================================================
+// PROJECT CONFIG
+// ============================================================================
+
+describe("Project Config", () => {
+  it("should route each project to its own Discord channel", async () => {
+    const userServer = startServer()
+    const projectServer = startServer()
+    const module = await loadModule({ discord: { webhookUrl: userServer.url, events: ["error"] } })
+    const projectHooks: PluginHooks = await module.HyprNotifierPlugin(
+      createMockContext({ worktree: createProject({ discord: { webhookUrl: projectServer.url } }) }) as any
+    )
+    const otherHooks: PluginHooks = await module.HyprNotifierPlugin(
+      createMockContext({ worktree: createProject({}) }) as any
+    )
+
+    await projectHooks.event({ event: eventFixtures.sessionErrorEvent })
+    await otherHooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    expect(projectServer.requests).toHaveLength(1)
+    expect(userServer.requests).toHaveLength(1)
+  })
+
+  it("should merge project settings one level deep over user settings", async () => {
+    const server = startServer()
+    const worktree = createProject({
+      messages: { error: "Project Error" },
+      discord: { events: ["permission"] },
+    })
+    const module = await loadModule({
+      discord: { webhookUrl: server.url, events: ["error"] },
+      messages: { permission: "User Permission" },
+    })
+    const hooks: PluginHooks = await module.HyprNotifierPlugin(createMockContext({ worktree }) as any)
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    expect(server.requests).toHaveLength(1)
+    expect(JSON.parse(server.requests[0]!.body).embeds[0].title).toBe("User Permission")
+  })
+
+  it("should apply project settings without a user config file", async () => {
+    const server = startServer()
+    rmSync(join(home, ".config", "opencode", "opencode-hyprland.json"), { force: true })
+    const module = await import(`../src/hypr-notifier.ts?instance=${moduleInstance++}`)
+    const worktree = createProject({ notification: false, discord: { webhookUrl: server.url } })
+    const hooks: PluginHooks = await module.HyprNotifierPlugin(createMockContext({ worktree }) as any)
+
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    expect(JSON.parse(server.requests[0]!.body).embeds[0].title).toBe("OpenCode Session Error")
+  })
+})
+
+// ============================================================================
+// NTFY
+// ============================================================================
+
+describe("ntfy Backend", () => {
+  it("should publish JSON with priority mapped from urgency", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({
+      ntfy: { server: `${server.url}/ntfy/`, topic: "alerts" },
+      urgency: { permission: "low", session: "normal", error: "critical" },
+    })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+    await hooks.event({ event: eventFixtures.sessionCompleteEvent })
+    await hooks.event({ event: eventFixtures.sessionErrorEvent })
+
+    expect(server.requests.map((request) => request.path)).toEqual(["/ntfy", "/ntfy", "/ntfy"])
+    const payloads = server.requests.map((request) => JSON.parse(request.body))
+    expect(payloads.map((payload) => payload.priority)).toEqual([2, 3, 5])
+    expect(payloads[2]).toEqual({
+      topic: "alerts",
+      title: "OpenCode Session Error",
+      message: "Rate limit exceeded\nError: APIError\nSession: sess_error_123",
+      priority: 5,
+    })
+    expect(server.requests.every((request) => request.headers.get("Authorization") === null)).toBe(true)
+  })
+
+  it("should send a bearer token when configured", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({ ntfy: { server: server.url, topic: "alerts", token: "tk_123" } })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+
+    expect(server.requests[0]!.headers.get("Authorization")).toBe("Bearer tk_123")
+  })
+})
+
+// ============================================================================
+// MATRIX
+// ============================================================================
+
+describe("Matrix Backend", () => {
+  it("should PUT an HTML-escaped message into the room", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({
+      matrix: { homeserver: `${server.url}/`, roomId: "!room:example.org", accessToken: "syt_token" },
+      messages: { error: 'Build <failed> & "stopped"' },
+    })
+
+    await hooks.event({
+      event: { type: "session.error", properties: { sessionID: "s1", error: { name: "APIError", data: { message: "a < b" } } } },
+    })
+
+    expect(server.requests).toHaveLength(1)
+    const request = server.requests[0]!
+    expect(request.method).toBe("PUT")
+    expect(request.path).toMatch(/^\/_matrix\/client\/v3\/rooms\/%21room%3Aexample\.org\/send\/m\.room\.message\/[^/]+$/)
+    expect(request.headers.get("Authorization")).toBe("Bearer syt_token")
+    expect(JSON.parse(request.body)).toEqual({
+      msgtype: "m.text",
+      body: 'Build <failed> & "stopped"\na < b\nError: APIError\nSession: s1',
+      format: "org.matrix.custom.html",
+      formatted_body:
+        "<strong>Build &lt;failed&gt; &amp; &quot;stopped&quot;</strong><br>a &lt; b<br>Error: APIError<br>Session: s1",
+    })
+  })
+
+  it("should use a fresh transaction ID per message", async () => {
+    const server = startServer()
+    const hooks = await loadPlugin({
+      matrix: { homeserver: server.url, roomId: "!room:example.org", accessToken: "syt_token" },
+    })
+
+    await hooks.event({ event: eventFixtures.permissionEvent })
+    await hooks.event({ event: eventFixtures.sessionCompleteEvent })
+
+    const [first, second] = server.requests.map((request) => request.path)
+    expect(first).not.toBe(second)
+  })
+})
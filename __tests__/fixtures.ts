@@ -0,0 +1,132 @@
+/**
+ * Shared mock context and event fixtures for the plugin tests
+ */
+
+// ============================================================================
+// MOCK CONTEXT
+// ============================================================================
+
+export interface MockContext {
+  readonly $: object
+  readonly client: object
+  readonly project: object
+  readonly directory: string
+  readonly worktree: string
+}
+
+export function createMockContext(overrides: Partial<MockContext> = {}): MockContext {
+  return Object.freeze({
+    $: {},
+    client: {},
+    project: { name: "test" },
+    directory: "/tmp",
+    worktree: "/tmp",
+    ...overrides,
+  })
+}
+
+// ============================================================================
+// EVENT FIXTURES
+// ============================================================================
+
+export const eventFixtures = {
+  permissionEvent: {
+    type: "permission.updated" as const,
+    properties: {
+      type: "network",
+      title: "Make HTTP Request",
+      pattern: "api.example.com",
+      sessionID: "sess_abc123",
+    },
+  },
+
+  permissionEventMinimal: {
+    type: "permission.updated" as const,
+    properties: {},
+  },
+
+  permissionEventWithArray: {
+    type: "permission.updated" as const,
+    properties: {
+      type: "file",
+      title: "Read File",
+      pattern: ["*.json", "*.yaml"],
+    },
+  },
+
+  permissionEventWithNull: {
+    type: "permission.updated" as const,
+    properties: null,
+  },
+
+  sessionCompleteEvent: {
+    type: "session.complete" as const,
+    properties: {
+      sessionID: "sess_complete_123",
+      info: {
+        id: "sess_complete_123",
+        title: "Code Review Task",
+      },
+    },
+  },
+
+  taskCompleteEvent: {
+    type: "task.complete" as const,
+    properties: {
+      taskID: "task_complete_456",
+      title: "API Development",
+      description: "Implemented new API endpoints",
+      sessionID: "sess_abc123",
+    },
+  },
+
+  taskCompleteEventWithId: {
+    type: "task.complete" as const,
+    properties: {
+      id: "task_id_789",
+      title: "Bug Fix",
+    },
+  },
+
+  taskCompleteEventMinimal: {
+    type: "task.complete" as const,
+    properties: {},
+  },
+
+  sessionErrorEvent: {
+    type: "session.error" as const,
+    properties: {
+      sessionID: "sess_error_123",
+      error: {
+        name: "APIError",
+        data: { message: "Rate limit exceeded" },
+      },
+    },
+  },
+
+  sessionAbortedEvent: {
+    type: "session.error" as const,
+    properties: {
+      sessionID: "sess_error_123",
+      error: {
+        name: "MessageAbortedError",
+        data: { message: "The operation was aborted." },
+      },
+    },
+  },
+
+  sessionErrorEventMinimal: {
+    type: "session.error" as const,
+    properties: {},
+  },
+
+  idleEvent: {
+    type: "session.idle" as const,
+    properties: { sessionID: "sess_idle_123" },
+  },
+
+  unknownEvent: {
+    type: "unknown.event.type" as const,
+    properties: { title: "Unknown Event" },
+  },
+}
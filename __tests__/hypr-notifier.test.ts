@@ -6,102 +6,7 @@
 
 import { describe, it, expect, beforeEach, afterEach, spyOn } from "bun:test"
 import { HyprNotifierPlugin } from "../src/hypr-notifier"
-
-// ============================================================================
-// MOCK CONTEXT
-// ============================================================================
-
-interface MockContext {
-  readonly $: object
-  readonly client: object
-  readonly project: object
-  readonly directory: string
-  readonly worktree: string
-}
-
-function createMockContext(): MockContext {
-  return Object.freeze({
-    $: {},
-    client: {},
-    project: { name: "test" },
-    directory: "/tmp",
-    worktree: "/tmp",
-  })
-}
-
-// ============================================================================
-// EVENT FIXTURES
-// ============================================================================
-
-const eventFixtures = {
-  permissionEvent: {
-    type: "permission.updated" as const,
-    properties: {
-      type: "network",
-      title: "Make HTTP Request",
-      pattern: "api.example.com",
-      sessionID: "sess_abc123",
-    },
-  },
-
-  permissionEventMinimal: {
-    type: "permission.updated" as const,
-    properties: {},
-  },
-
-  permissionEventWithArray: {
-    type: "permission.updated" as const,
-    properties: {
-      type: "file",
-      title: "Read File",
-      pattern: ["*.json", "*.yaml"],
-    },
-  },
-
-  permissionEventWithNull: {
-    type: "permission.updated" as const,
-    properties: null,
-  },
-
-  sessionCompleteEvent: {
-    type: "session.complete" as const,
-    properties: {
-      sessionID: "sess_complete_123",
-      info: {
-        id: "sess_complete_123",
-        title: "Code Review Task",
-      },
-    },
-  },
-
-  taskCompleteEvent: {
-    type: "task.complete" as const,
-    properties: {
-      taskID: "task_complete_456",
-      title: "API Development",
-      description: "Implemented new API endpoints",
-      sessionID: "sess_abc123",
-    },
-  },
-
-  taskCompleteEventWithId: {
-    type: "task.complete" as const,
-    properties: {
-      id: "task_id_789",
-      title: "Bug Fix",
-    },
-  },
-
-  taskCompleteEventMinimal: {
-    type: "task.complete" as const,
-    properties: {},
-  },
-
-  unknownEvent: {
-    type: "unknown.event.type" as const,
-    properties: { title: "Unknown Event" },
-  },
-}
+import { createMockContext, eventFixtures } from "./fixtures"
 
 // ============================================================================
 // PLUGIN INITIALIZATION
@@ -196,6 +101,27 @@ describe("Task Complete Events", () => {
   })
 })
 
+// ============================================================================
+// SESSION ERROR EVENTS
+// ============================================================================
+
+describe("Session Error Events", () => {
+  let hookFunction: { event: (input: { event: any }) => Promise<void> }
+
+  beforeEach(async () => {
+    const ctx = createMockContext()
+    hookFunction = await HyprNotifierPlugin(ctx as any)
+  })
+
+  it("should handle session.error with name, message and sessionID", async () => {
+    await expect(hookFunction.event({ event: eventFixtures.sessionErrorEvent })).resolves.toBeUndefined()
+  })
+
+  it("should handle minimal session.error event", async () => {
+    await expect(hookFunction.event({ event: eventFixtures.sessionErrorEventMinimal })).resolves.toBeUndefined()
+  })
+})
+
 // ============================================================================
 // UNKNOWN EVENTS
 // ============================================================================
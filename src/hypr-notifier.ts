@@ -2,13 +2,14 @@
  * OpenCode Hyprland Notifier Plugin
  * 
  * Sends desktop notifications via notify-send for permission requests,
- * session events, and task completions.
+ * session events, task completions, and session errors.
  */
 
 import type { Plugin } from "@opencode-ai/plugin"
-import { readFileSync, existsSync } from "fs"
+import { readFileSync, existsSync, writeFileSync, rmSync, mkdtempSync } from "fs"
 import { join } from "path"
-import { homedir } from "os"
+import { homedir, tmpdir } from "os"
+import { createHmac, randomUUID } from "crypto"
 
 // ============================================================================
 // TYPES
@@ -16,14 +17,39 @@ import { homedir } from "os"
 
 type Urgency = "low" | "normal" | "critical"
 
+type EventKey = "permission" | "session" | "idle" | "error"
+
+type ChatWebhookConfig = Readonly<{ webhookUrl: string; events: readonly EventKey[] }>
+
+type WebhookConfig = Readonly<{ url: string; secret: string | null; events: readonly EventKey[]; retries: number }>
+
+type NtfyConfig = Readonly<{ server: string; topic: string; token: string | null; events: readonly EventKey[] }>
+
+type MatrixConfig = Readonly<{ homeserver: string; roomId: string; accessToken: string; events: readonly EventKey[] }>
+
+type EmailConfig = Readonly<{
+  url: string
+  from: string
+  to: readonly string[]
+  username: string | null
+  password: string | null
+  events: readonly EventKey[]
+}>
+
 type HyprlandConfig = Readonly<{
   notification: boolean
   timeout: number
   transient: boolean
-  icons: Readonly<{ permission: string; session: string; idle: string }>
-  urgency: Readonly<{ permission: Urgency; session: Urgency; idle: Urgency }>
-  messages: Readonly<{ permission: string; session: string; idle: string }>
-  category: Readonly<{ permission: string; session: string; idle: string }>
+  icons: Readonly<{ permission: string; session: string; idle: string; error: string }>
+  urgency: Readonly<{ permission: Urgency; session: Urgency; idle: Urgency; error: Urgency }>
+  messages: Readonly<{ permission: string; session: string; idle: string; error: string }>
+  category: Readonly<{ permission: string; session: string; idle: string; error: string }>
+  slack: ChatWebhookConfig | null
+  discord: ChatWebhookConfig | null
+  webhook: WebhookConfig | null
+  email: EmailConfig | null
+  ntfy: NtfyConfig | null
+  matrix: MatrixConfig | null
 }>
 
 type OpenCodeEvent = Readonly<{ type: string; properties: unknown }>
@@ -32,6 +58,9 @@ type OpenCodeEvent = Readonly<{ type: string; properties: unknown }>
 // CONSTANTS
 // ============================================================================
 
+// Remote backends skip idle by default: it fires after every response
+const DEFAULT_REMOTE_EVENTS: readonly EventKey[] = Object.freeze(["permission", "session", "error"] as const)
+
 const DEFAULT_CONFIG: HyprlandConfig = Object.freeze({
   notification: true,
   timeout: 15000,
@@ -40,30 +69,65 @@ const DEFAULT_CONFIG: HyprlandConfig = Object.freeze({
     permission: "dialog-password",
     session: "emblem-ok-symbolic",
     idle: "dialog-information",
+    error: "dialog-error",
   }),
   urgency: Object.freeze({
     permission: "critical",
     session: "normal",
     idle: "normal",
+    error: "critical",
   }),
   messages: Object.freeze({
     permission: "OpenCode Needs Your Attention",
     session: "OpenCode Session Idle",
     idle: "OpenCode Waiting for Input",
+    error: "OpenCode Session Error",
   }),
   category: Object.freeze({
     permission: "im.received",
     session: "im.received",
     idle: "im.received",
+    error: "im.error",
   }),
+  slack: null,
+  discord: null,
+  webhook: null,
+  email: null,
+  ntfy: null,
+  matrix: null,
+})
+
+const NTFY_PRIORITIES: Readonly<Record<Urgency, number>> = Object.freeze({
+  low: 2,
+  normal: 3,
+  critical: 5,
 })
 
+const DEFAULT_NTFY_SERVER = "https://ntfy.sh"
+
+const HTTP_TIMEOUT = 10000
+
+const DEFAULT_WEBHOOK_RETRIES = 3
+const MAX_WEBHOOK_RETRIES = 10
+const WEBHOOK_RETRY_BASE_DELAY = 500
+const WEBHOOK_RETRY_MAX_DELAY = 30000
+
+const DISCORD_COLORS: Readonly<Record<Urgency, number>> = Object.freeze({
+  low: 0x95a5a6,
+  normal: 0x3498db,
+  critical: 0xe74c3c,
+})
+
+// Discord rejects embeds over these lengths with a 400
+const DISCORD_TITLE_LIMIT = 256
+const DISCORD_DESCRIPTION_LIMIT = 4096
+
 // ============================================================================
-// STATE (singleton to prevent double initialization)
+// STATE (singleton to prevent double initialization, config cached per project)
 // ============================================================================
 
 let initialized = false
-let cachedConfig: HyprlandConfig | null = null
+const cachedConfigs = new Map<string, HyprlandConfig>()
 let idleNotificationSent = false
 
 // ============================================================================
@@ -83,9 +147,13 @@ function log(level: "debug" | "info" | "warn" | "error", message: string, contex
 // SHELL EXECUTION
 // ============================================================================
 
-async function executeCommand(command: string[]): Promise<{ exitCode: number | null }> {
+async function executeCommand(command: string[], stdin?: string): Promise<{ exitCode: number | null }> {
   try {
-    const proc = Bun.spawn(command, { stdout: "pipe", stderr: "pipe" })
+    const proc = Bun.spawn(command, {
+      stdin: stdin === undefined ? null : new Response(stdin),
+      stdout: "pipe",
+      stderr: "pipe",
+    })
     await proc.exited
     return { exitCode: proc.exitCode }
   } catch {
@@ -93,6 +161,28 @@ async function executeCommand(command: string[]): Promise<{ exitCode: number | n
   }
 }
 
+// ============================================================================
+// HTTP
+// ============================================================================
+
+async function request(
+  method: "POST" | "PUT",
+  url: string,
+  body: string,
+  headers: Record<string, string>
+): Promise<{ status: number | null }> {
+  try {
+    const response = await fetch(url, { method, headers, body, signal: AbortSignal.timeout(HTTP_TIMEOUT) })
+    return { status: response.status }
+  } catch {
+    return { status: null }
+  }
+}
+
+async function postJSON(url: string, payload: unknown, headers: Record<string, string> = {}): Promise<{ status: number | null }> {
+  return request("POST", url, JSON.stringify(payload), { "Content-Type": "application/json", ...headers })
+}
+
 // ============================================================================
 // CONFIGURATION
 // ============================================================================
@@ -101,60 +191,174 @@ function isValidUrgency(value: unknown): value is Urgency {
   return value === "low" || value === "normal" || value === "critical"
 }
 
+function isValidEventKey(value: unknown): value is EventKey {
+  return value === "permission" || value === "session" || value === "idle" || value === "error"
+}
+
 function getConfigProperty(obj: unknown, key: string): unknown {
   if (typeof obj !== "object" || obj === null) return undefined
   return (obj as Record<string, unknown>)[key]
 }
 
-function loadConfig(): HyprlandConfig {
-  if (cachedConfig !== null) return cachedConfig
+function parseEvents(value: unknown): readonly EventKey[] {
+  if (!Array.isArray(value)) return DEFAULT_REMOTE_EVENTS
+  return Object.freeze(value.filter(isValidEventKey))
+}
+
+function parseChatWebhookConfig(obj: unknown): ChatWebhookConfig | null {
+  const webhookUrl = getConfigProperty(obj, "webhookUrl")
+  if (typeof webhookUrl !== "string" || webhookUrl === "") return null
+  return Object.freeze({ webhookUrl, events: parseEvents(getConfigProperty(obj, "events")) })
+}
+
+function parseWebhookConfig(obj: unknown): WebhookConfig | null {
+  const url = getConfigProperty(obj, "url")
+  if (typeof url !== "string" || url === "") return null
+  const secret = getConfigProperty(obj, "secret")
+  const retries = getConfigProperty(obj, "retries")
+  return Object.freeze({
+    url,
+    secret: typeof secret === "string" && secret !== "" ? secret : null,
+    events: parseEvents(getConfigProperty(obj, "events")),
+    retries: typeof retries === "number" && Number.isInteger(retries) && retries >= 0 ? Math.min(retries, MAX_WEBHOOK_RETRIES) : DEFAULT_WEBHOOK_RETRIES,
+  })
+}
+
+function parseNtfyConfig(obj: unknown): NtfyConfig | null {
+  const topic = getConfigProperty(obj, "topic")
+  if (typeof topic !== "string" || topic === "") return null
+  const server = getConfigProperty(obj, "server")
+  const token = getConfigProperty(obj, "token")
+  return Object.freeze({
+    server: typeof server === "string" && server !== "" ? server.replace(/\/+$/, "") : DEFAULT_NTFY_SERVER,
+    topic,
+    token: typeof token === "string" && token !== "" ? token : null,
+    events: parseEvents(getConfigProperty(obj, "events")),
+  })
+}
 
-  const configPath = join(homedir(), ".config", "opencode", "opencode-hyprland.json")
+function parseMatrixConfig(obj: unknown): MatrixConfig | null {
+  const homeserver = getConfigProperty(obj, "homeserver")
+  const roomId = getConfigProperty(obj, "roomId")
+  const accessToken = getConfigProperty(obj, "accessToken")
+  if (typeof homeserver !== "string" || homeserver === "") return null
+  if (typeof roomId !== "string" || roomId === "") return null
+  if (typeof accessToken !== "string" || accessToken === "") return null
+  return Object.freeze({
+    homeserver: homeserver.replace(/\/+$/, ""),
+    roomId,
+    accessToken,
+    events: parseEvents(getConfigProperty(obj, "events")),
+  })
+}
 
-  if (!existsSync(configPath)) {
-    cachedConfig = DEFAULT_CONFIG
-    return DEFAULT_CONFIG
+// Bare ASCII addresses only: RFC 2047 encoded-words are not allowed inside an address
+function isBareAddress(value: string): boolean {
+  return /^[A-Za-z0-9.!#$%&'*+/=?^_`{|}~-]+@[A-Za-z0-9.-]+$/.test(value)
+}
+
+function parseEmailConfig(obj: unknown): EmailConfig | null {
+  const url = getConfigProperty(obj, "url")
+  const from = getConfigProperty(obj, "from")
+  const toValue = getConfigProperty(obj, "to")
+  const to = typeof toValue === "string" ? [toValue] : Array.isArray(toValue) ? toValue.filter((r): r is string => typeof r === "string") : []
+  if (typeof url !== "string" || url === "" || typeof from !== "string" || from === "" || to.length === 0) return null
+  if (!isBareAddress(from) || !to.every(isBareAddress)) {
+    log("warn", "Email disabled: from and to must be plain addresses like user@example.com", { from, to })
+    return null
   }
+  const username = getConfigProperty(obj, "username")
+  const password = getConfigProperty(obj, "password")
+  return Object.freeze({
+    url,
+    from,
+    to: Object.freeze(to),
+    username: typeof username === "string" && username !== "" ? username : null,
+    password: typeof password === "string" ? password : null,
+    events: parseEvents(getConfigProperty(obj, "events")),
+  })
+}
 
-  try {
-    const userConfig = JSON.parse(readFileSync(configPath, "utf-8")) as Record<string, unknown>
-
-    const urgencyObj = userConfig["urgency"]
-    const messagesObj = userConfig["messages"]
-    const iconsObj = userConfig["icons"]
-    const categoryObj = userConfig["category"]
-
-    cachedConfig = Object.freeze({
-      notification: typeof userConfig["notification"] === "boolean" ? userConfig["notification"] : DEFAULT_CONFIG.notification,
-      timeout: typeof userConfig["timeout"] === "number" ? userConfig["timeout"] : DEFAULT_CONFIG.timeout,
-      transient: typeof userConfig["transient"] === "boolean" ? userConfig["transient"] : DEFAULT_CONFIG.transient,
-      urgency: Object.freeze({
-        permission: isValidUrgency(getConfigProperty(urgencyObj, "permission")) ? getConfigProperty(urgencyObj, "permission") as Urgency : DEFAULT_CONFIG.urgency.permission,
-        session: isValidUrgency(getConfigProperty(urgencyObj, "session")) ? getConfigProperty(urgencyObj, "session") as Urgency : DEFAULT_CONFIG.urgency.session,
-        idle: isValidUrgency(getConfigProperty(urgencyObj, "idle")) ? getConfigProperty(urgencyObj, "idle") as Urgency : DEFAULT_CONFIG.urgency.idle,
-      }),
-      messages: Object.freeze({
-        permission: typeof getConfigProperty(messagesObj, "permission") === "string" ? getConfigProperty(messagesObj, "permission") as string : DEFAULT_CONFIG.messages.permission,
-        session: typeof getConfigProperty(messagesObj, "session") === "string" ? getConfigProperty(messagesObj, "session") as string : DEFAULT_CONFIG.messages.session,
-        idle: typeof getConfigProperty(messagesObj, "idle") === "string" ? getConfigProperty(messagesObj, "idle") as string : DEFAULT_CONFIG.messages.idle,
-      }),
-      icons: Object.freeze({
-        permission: typeof getConfigProperty(iconsObj, "permission") === "string" ? getConfigProperty(iconsObj, "permission") as string : DEFAULT_CONFIG.icons.permission,
-        session: typeof getConfigProperty(iconsObj, "session") === "string" ? getConfigProperty(iconsObj, "session") as string : DEFAULT_CONFIG.icons.session,
-        idle: typeof getConfigProperty(iconsObj, "idle") === "string" ? getConfigProperty(iconsObj, "idle") as string : DEFAULT_CONFIG.icons.idle,
-      }),
-      category: Object.freeze({
-        permission: typeof getConfigProperty(categoryObj, "permission") === "string" ? getConfigProperty(categoryObj, "permission") as string : DEFAULT_CONFIG.category.permission,
-        session: typeof getConfigProperty(categoryObj, "session") === "string" ? getConfigProperty(categoryObj, "session") as string : DEFAULT_CONFIG.category.session,
-        idle: typeof getConfigProperty(categoryObj, "idle") === "string" ? getConfigProperty(categoryObj, "idle") as string : DEFAULT_CONFIG.category.idle,
-      }),
-    })
+function isPlainObject(value: unknown): value is Record<string, unknown> {
+  return typeof value === "object" && value !== null && !Array.isArray(value)
+}
 
-    return cachedConfig
+function readConfigFile(path: string): Record<string, unknown> | null {
+  if (!existsSync(path)) return null
+  try {
+    const parsed: unknown = JSON.parse(readFileSync(path, "utf-8"))
+    return isPlainObject(parsed) ? parsed : null
   } catch {
-    cachedConfig = DEFAULT_CONFIG
-    return DEFAULT_CONFIG
+    return null
+  }
+}
+
+// Project settings win, merged one level deep so a project can override a single message or webhook URL
+function mergeConfig(base: Record<string, unknown>, override: Record<string, unknown>): Record<string, unknown> {
+  const merged: Record<string, unknown> = { ...base }
+  for (const [key, value] of Object.entries(override)) {
+    const current = merged[key]
+    merged[key] = isPlainObject(current) && isPlainObject(value) ? { ...current, ...value } : value
   }
+  return merged
+}
+
+function parseConfig(rawConfig: Record<string, unknown>): HyprlandConfig {
+  const urgencyObj = rawConfig["urgency"]
+  const messagesObj = rawConfig["messages"]
+  const iconsObj = rawConfig["icons"]
+  const categoryObj = rawConfig["category"]
+
+  return Object.freeze({
+    notification: typeof rawConfig["notification"] === "boolean" ? rawConfig["notification"] : DEFAULT_CONFIG.notification,
+    timeout: typeof rawConfig["timeout"] === "number" ? rawConfig["timeout"] : DEFAULT_CONFIG.timeout,
+    transient: typeof rawConfig["transient"] === "boolean" ? rawConfig["transient"] : DEFAULT_CONFIG.transient,
+    urgency: Object.freeze({
+      permission: isValidUrgency(getConfigProperty(urgencyObj, "permission")) ? getConfigProperty(urgencyObj, "permission") as Urgency : DEFAULT_CONFIG.urgency.permission,
+      session: isValidUrgency(getConfigProperty(urgencyObj, "session")) ? getConfigProperty(urgencyObj, "session") as Urgency : DEFAULT_CONFIG.urgency.session,
+      idle: isValidUrgency(getConfigProperty(urgencyObj, "idle")) ? getConfigProperty(urgencyObj, "idle") as Urgency : DEFAULT_CONFIG.urgency.idle,
+      error: isValidUrgency(getConfigProperty(urgencyObj, "error")) ? getConfigProperty(urgencyObj, "error") as Urgency : DEFAULT_CONFIG.urgency.error,
+    }),
+    messages: Object.freeze({
+      permission: typeof getConfigProperty(messagesObj, "permission") === "string" ? getConfigProperty(messagesObj, "permission") as string : DEFAULT_CONFIG.messages.permission,
+      session: typeof getConfigProperty(messagesObj, "session") === "string" ? getConfigProperty(messagesObj, "session") as string : DEFAULT_CONFIG.messages.session,
+      idle: typeof getConfigProperty(messagesObj, "idle") === "string" ? getConfigProperty(messagesObj, "idle") as string : DEFAULT_CONFIG.messages.idle,
+      error: typeof getConfigProperty(messagesObj, "error") === "string" ? getConfigProperty(messagesObj, "error") as string : DEFAULT_CONFIG.messages.error,
+    }),
+    icons: Object.freeze({
+      permission: typeof getConfigProperty(iconsObj, "permission") === "string" ? getConfigProperty(iconsObj, "permission") as string : DEFAULT_CONFIG.icons.permission,
+      session: typeof getConfigProperty(iconsObj, "session") === "string" ? getConfigProperty(iconsObj, "session") as string : DEFAULT_CONFIG.icons.session,
+      idle: typeof getConfigProperty(iconsObj, "idle") === "string" ? getConfigProperty(iconsObj, "idle") as string : DEFAULT_CONFIG.icons.idle,
+      error: typeof getConfigProperty(iconsObj, "error") === "string" ? getConfigProperty(iconsObj, "error") as string : DEFAULT_CONFIG.icons.error,
+    }),
+    category: Object.freeze({
+      permission: typeof getConfigProperty(categoryObj, "permission") === "string" ? getConfigProperty(categoryObj, "permission") as string : DEFAULT_CONFIG.category.permission,
+      session: typeof getConfigProperty(categoryObj, "session") === "string" ? getConfigProperty(categoryObj, "session") as string : DEFAULT_CONFIG.category.session,
+      idle: typeof getConfigProperty(categoryObj, "idle") === "string" ? getConfigProperty(categoryObj, "idle") as string : DEFAULT_CONFIG.category.idle,
+      error: typeof getConfigProperty(categoryObj, "error") === "string" ? getConfigProperty(categoryObj, "error") as string : DEFAULT_CONFIG.category.error,
+    }),
+    slack: parseChatWebhookConfig(rawConfig["slack"]),
+    discord: parseChatWebhookConfig(rawConfig["discord"]),
+    webhook: parseWebhookConfig(rawConfig["webhook"]),
+    email: parseEmailConfig(rawConfig["email"]),
+    ntfy: parseNtfyConfig(rawConfig["ntfy"]),
+    matrix: parseMatrixConfig(rawConfig["matrix"]),
+  })
+}
+
+function loadConfig(projectDir: string): HyprlandConfig {
+  const cached = cachedConfigs.get(projectDir)
+  if (cached !== undefined) return cached
+
+  const userConfig = readConfigFile(join(homedir(), ".config", "opencode", "opencode-hyprland.json"))
+  const projectConfig = readConfigFile(join(projectDir, ".opencode", "opencode-hyprland.json"))
+
+  const config =
+    userConfig === null && projectConfig === null
+      ? DEFAULT_CONFIG
+      : parseConfig(mergeConfig(userConfig ?? {}, projectConfig ?? {}))
+  cachedConfigs.set(projectDir, config)
+  return config
 }
 
 // ============================================================================
@@ -178,6 +382,151 @@ async function sendNotification(
   await executeCommand(command)
 }
 
+function escapeSlack(text: string): string {
+  return text.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;")
+}
+
+async function sendSlack(slack: ChatWebhookConfig, title: string, body: string): Promise<void> {
+  const { status } = await postJSON(slack.webhookUrl, {
+    text: `*${escapeSlack(title)}*\n${escapeSlack(body)}`,
+  })
+  if (status === null || status < 200 || status >= 300) {
+    log("warn", "Slack notification failed", { status })
+  }
+}
+
+// Counts code points so astral characters such as emoji are never split into lone surrogates
+function truncate(text: string, limit: number): string {
+  const chars = Array.from(text)
+  return chars.length <= limit ? text : `${chars.slice(0, limit - 1).join("")}…`
+}
+
+async function sendDiscord(discord: ChatWebhookConfig, title: string, body: string, urgency: Urgency): Promise<void> {
+  const { status } = await postJSON(discord.webhookUrl, {
+    username: "OpenCode",
+    allowed_mentions: { parse: [] },
+    embeds: [
+      {
+        title: truncate(title, DISCORD_TITLE_LIMIT),
+        description: truncate(body, DISCORD_DESCRIPTION_LIMIT),
+        color: DISCORD_COLORS[urgency],
+      },
+    ],
+  })
+  if (status === null || status < 200 || status >= 300) {
+    log("warn", "Discord notification failed", { status })
+  }
+}
+
+async function sendNtfy(ntfy: NtfyConfig, title: string, body: string, urgency: Urgency): Promise<void> {
+  const headers: Record<string, string> = ntfy.token !== null ? { Authorization: `Bearer ${ntfy.token}` } : {}
+  const { status } = await postJSON(
+    ntfy.server,
+    { topic: ntfy.topic, title, message: body, priority: NTFY_PRIORITIES[urgency] },
+    headers
+  )
+  if (status === null || status < 200 || status >= 300) {
+    log("warn", "ntfy notification failed", { status })
+  }
+}
+
+function escapeHtml(text: string): string {
+  return text.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;").replace(/"/g, "&quot;")
+}
+
+async function sendMatrix(matrix: MatrixConfig, title: string, body: string): Promise<void> {
+  const url = `${matrix.homeserver}/_matrix/client/v3/rooms/${encodeURIComponent(matrix.roomId)}/send/m.room.message/${randomUUID()}`
+  const { status } = await request(
+    "PUT",
+    url,
+    JSON.stringify({
+      msgtype: "m.text",
+      body: `${title}\n${body}`,
+      format: "org.matrix.custom.html",
+      formatted_body: `<strong>${escapeHtml(title)}</strong><br>${escapeHtml(body).replace(/\n/g, "<br>")}`,
+    }),
+    { "Content-Type": "application/json", Authorization: `Bearer ${matrix.accessToken}` }
+  )
+  if (status === null || status < 200 || status >= 300) {
+    log("warn", "Matrix notification failed", { status })
+  }
+}
+
+function isRetryableStatus(status: number | null): boolean {
+  return status === null || status === 429 || status >= 500
+}
+
+async function sendWebhook(webhook: WebhookConfig, payload: Record<string, unknown>): Promise<void> {
+  // One ID per event, reused across retries, so receivers can drop duplicates after a timed-out attempt
+  const id = randomUUID()
+  const body = JSON.stringify({ id, ...payload })
+  const headers: Record<string, string> = { "Content-Type": "application/json", "X-Hypr-Notifier-Delivery": id }
+  if (webhook.secret !== null) {
+    headers["X-Hypr-Notifier-Signature"] = `sha256=${createHmac("sha256", webhook.secret).update(body).digest("hex")}`
+  }
+
+  // Retry network errors, rate limits and server errors with exponential backoff
+  let status: number | null = null
+  let attempts = 0
+  while (attempts <= webhook.retries) {
+    if (attempts > 0) await Bun.sleep(Math.min(WEBHOOK_RETRY_BASE_DELAY * 2 ** (attempts - 1), WEBHOOK_RETRY_MAX_DELAY))
+    attempts++
+    status = (await request("POST", webhook.url, body, headers)).status
+    if (!isRetryableStatus(status)) break
+  }
+  if (status === null || status < 200 || status >= 300) {
+    log("warn", "Webhook delivery failed", { status, attempts })
+  }
+}
+
+function encodeHeader(value: string): string {
+  const singleLine = value.replace(/[\r\n]+/g, " ")
+  return /^[\x20-\x7e]*$/.test(singleLine) ? singleLine : `=?UTF-8?B?${Buffer.from(singleLine).toString("base64")}?=`
+}
+
+function buildEmailMessage(email: EmailConfig, title: string, body: string): string {
+  const encodedBody = Buffer.from(body).toString("base64").replace(/.{76}/g, "$&\r\n")
+  return [
+    `From: ${email.from}`,
+    `To: ${email.to.join(", ")}`,
+    `Subject: ${encodeHeader(title)}`,
+    `Date: ${new Date().toUTCString()}`,
+    `Message-ID: <${randomUUID()}@${email.from.slice(email.from.lastIndexOf("@") + 1)}>`,
+    "MIME-Version: 1.0",
+    "Content-Type: text/plain; charset=utf-8",
+    "Content-Transfer-Encoding: base64",
+    "",
+    encodedBody,
+  ].join("\r\n")
+}
+
+async function sendEmail(email: EmailConfig, title: string, body: string): Promise<void> {
+  // The message holds no secrets, so it is the part that goes through a file
+  const messageDir = mkdtempSync(join(tmpdir(), "hypr-notifier-"))
+  const messagePath = join(messageDir, "message.eml")
+
+  const command: string[] = ["curl", "--silent", "--show-error", "--url", email.url, "--mail-from", email.from]
+  for (const recipient of email.to) command.push("--mail-rcpt", recipient)
+  command.push("--upload-file", messagePath)
+
+  // Credentials are read by curl from stdin so they never touch disk or the process list,
+  // and TLS is required so they are never sent in cleartext over smtp:// (STARTTLS)
+  let config: string | undefined
+  if (email.username !== null) {
+    const user = `${email.username}:${email.password ?? ""}`.replace(/\\/g, "\\\\").replace(/"/g, '\\"')
+    config = `user = "${user}"\n`
+    command.push("--ssl-reqd", "--config", "-")
+  }
+
+  try {
+    writeFileSync(messagePath, buildEmailMessage(email, title, body), { mode: 0o600 })
+    const { exitCode } = await executeCommand(command, config)
+    if (exitCode !== 0) log("warn", "Email notification failed", { exitCode })
+  } finally {
+    rmSync(messageDir, { recursive: true, force: true })
+  }
+}
+
 // ============================================================================
 // EVENT BODY BUILDERS
 // ============================================================================
@@ -219,6 +568,15 @@ function buildBody(eventType: string, props: unknown): string {
     return body
   }
 
+  if (eventType === "session.error") {
+    const error = (typeof p.error === "object" && p.error !== null ? p.error : {}) as Record<string, unknown>
+    const data = (typeof error.data === "object" && error.data !== null ? error.data : {}) as Record<string, unknown>
+    let body = typeof data.message === "string" ? data.message : "OpenCode encountered an error"
+    if (typeof error.name === "string") body += `\nError: ${error.name}`
+    if (typeof p.sessionID === "string") body += `\nSession: ${p.sessionID}`
+    return body
+  }
+
   if (eventType === "session.idle") {
     const sessionID = (p.sessionID ?? p.id ?? "unknown") as string
     let body = "OpenCode has finished processing"
@@ -233,21 +591,26 @@ function buildBody(eventType: string, props: unknown): string {
 // EVENT HANDLERS
 // ============================================================================
 
-type EventKey = "permission" | "session" | "idle"
-
 const eventMap: Record<string, EventKey> = {
   "permission.updated": "permission",
   "session.complete": "session",
   "task.complete": "session",
   "session.idle": "idle",
+  "session.error": "error",
 }
 
-async function handleEvent(event: OpenCodeEvent, config: HyprlandConfig): Promise<void> {
-  if (!config.notification) return
+function isMessageAborted(props: unknown): boolean {
+  const error = getConfigProperty(props, "error")
+  return getConfigProperty(error, "name") === "MessageAbortedError"
+}
 
+async function handleEvent(event: OpenCodeEvent, config: HyprlandConfig): Promise<void> {
   const key = eventMap[event.type]
   if (!key) return
 
+  // opencode reports a user interrupt (Esc) as a session error, which is not a failure
+  if (key === "error" && isMessageAborted(event.properties)) return
+
   // For idle events, only send a single notification until session becomes active again
   if (key === "idle") {
     if (idleNotificationSent) {
@@ -261,24 +624,56 @@ async function handleEvent(event: OpenCodeEvent, config: HyprlandConfig): Promis
     idleNotificationSent = false
   }
 
+  // opencode emits session.idle right after a failed prompt loop, and the error notification already covers it
+  if (key === "error") {
+    idleNotificationSent = true
+  }
+
+  const title = config.messages[key]
   const body = buildBody(event.type, event.properties)
-  await sendNotification(
-    config.messages[key],
-    body,
-    config.urgency[key],
-    config.timeout,
-    config.icons[key],
-    config.category[key],
-    config.transient
-  )
+  const urgency = config.urgency[key]
+  const deliveries: Promise<void>[] = []
+  // "notification" only controls the desktop popup so remote backends work on headless machines
+  if (config.notification) {
+    deliveries.push(
+      sendNotification(
+        title,
+        body,
+        urgency,
+        config.timeout,
+        config.icons[key],
+        config.category[key],
+        config.transient
+      )
+    )
+  }
+  if (config.slack?.events.includes(key)) deliveries.push(sendSlack(config.slack, title, body))
+  if (config.discord?.events.includes(key)) deliveries.push(sendDiscord(config.discord, title, body, urgency))
+  if (config.ntfy?.events.includes(key)) deliveries.push(sendNtfy(config.ntfy, title, body, urgency))
+  if (config.matrix?.events.includes(key)) deliveries.push(sendMatrix(config.matrix, title, body))
+  if (config.email?.events.includes(key)) deliveries.push(sendEmail(config.email, title, body))
+  if (config.webhook?.events.includes(key)) {
+    deliveries.push(
+      sendWebhook(config.webhook, {
+        event: key,
+        type: event.type,
+        title,
+        body,
+        urgency,
+        properties: event.properties,
+        timestamp: new Date().toISOString(),
+      })
+    )
+  }
+  await Promise.all(deliveries)
 }
 
 // ============================================================================
 // PLUGIN EXPORT
 // ============================================================================
 
-export const HyprNotifierPlugin: Plugin = async () => {
-  const config = loadConfig()
+export const HyprNotifierPlugin: Plugin = async ({ worktree }) => {
+  const config = loadConfig(worktree)
 
   // Only log once
   if (!initialized) {
@@ -286,6 +681,12 @@ export const HyprNotifierPlugin: Plugin = async () => {
     log("info", "HyprNotifierPlugin initialized", {
       notifications: config.notification,
       timeout: config.timeout,
+      slack: config.slack !== null,
+      discord: config.discord !== null,
+      webhook: config.webhook !== null,
+      email: config.email !== null,
+      ntfy: config.ntfy !== null,
+      matrix: config.matrix !== null,
     })
   }
 